@@ -0,0 +1,85 @@
+package zapcloudwatchcore
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// MessageFormat selects how a zapcore.Entry is re-encoded into the message
+// string CloudWatch Logs receives.
+type MessageFormat int
+
+const (
+	// FormatEncoder uses Enc's own output verbatim, as CloudwatchCore has
+	// always done. Whatever format Enc produces (console, JSON, ...) is
+	// what ends up in CloudWatch Logs.
+	FormatEncoder MessageFormat = iota
+	// FormatJSON re-encodes the entry as a JSON object with stable
+	// top-level keys (level, timestamp, logger, caller, msg) and the
+	// entry's fields nested under a "fields" key, regardless of Enc.
+	FormatJSON
+	// FormatJSONFlat is like FormatJSON but merges fields into the
+	// top level instead of nesting them, so a Logs Insights query like
+	// `fields @message | filter request_id = "..."` can reach them
+	// directly. A field sharing a stable key's name loses to that key.
+	FormatJSONFlat
+)
+
+// structuredKeys are the stable top-level keys FormatJSON/FormatJSONFlat
+// emit, so downstream Logs Insights queries can rely on them regardless of
+// which fields a given entry carries. All but caller are always emitted;
+// caller is emitted only when the entry has one.
+const (
+	structuredKeyLevel     = "level"
+	structuredKeyTimestamp = "timestamp"
+	structuredKeyLogger    = "logger"
+	structuredKeyCaller    = "caller"
+	structuredKeyMsg       = "msg"
+)
+
+// encodeMessage produces the message string to send to CloudWatch Logs for
+// ent, either via c.enc (FormatEncoder) or as structured JSON.
+func (c *CloudwatchCore) encodeMessage(ent zapcore.Entry, fields []zapcore.Field) (string, error) {
+	if c.messageFormat == FormatEncoder {
+		buf, err := c.enc.EncodeEntry(ent, fields)
+		if err != nil {
+			return "", err
+		}
+		defer buf.Free()
+		return buf.String(), nil
+	}
+
+	out := map[string]interface{}{
+		structuredKeyLevel:     ent.Level.String(),
+		structuredKeyTimestamp: ent.Time.UnixNano() / int64(time.Millisecond),
+		structuredKeyLogger:    ent.LoggerName,
+		structuredKeyMsg:       ent.Message,
+	}
+	if ent.Caller.Defined {
+		out[structuredKeyCaller] = ent.Caller.String()
+	}
+
+	c.accumFieldsMu.Lock()
+	entryFields := mergedFields(c.accumFields, fields)
+	c.accumFieldsMu.Unlock()
+
+	switch c.messageFormat {
+	case FormatJSONFlat:
+		for k, v := range entryFields {
+			if _, reserved := out[k]; reserved {
+				continue
+			}
+			out[k] = v
+		}
+	default: // FormatJSON
+		out["fields"] = entryFields
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
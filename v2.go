@@ -0,0 +1,160 @@
+package zapcloudwatchcore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	cloudwatchlogsv2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	typesv2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/smithy-go"
+	"go.uber.org/zap/zapcore"
+)
+
+// CloudWatchLogsAPIv2 is the subset of the aws-sdk-go-v2 CloudWatch Logs
+// client NewCloudwatchCoreV2 depends on.
+type CloudWatchLogsAPIv2 interface {
+	DescribeLogGroups(ctx context.Context, params *cloudwatchlogsv2.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.DescribeLogGroupsOutput, error)
+	CreateLogGroup(ctx context.Context, params *cloudwatchlogsv2.CreateLogGroupInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.CreateLogGroupOutput, error)
+	DescribeLogStreams(ctx context.Context, params *cloudwatchlogsv2.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.DescribeLogStreamsOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogsv2.CreateLogStreamInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.CreateLogStreamOutput, error)
+	PutLogEvents(ctx context.Context, params *cloudwatchlogsv2.PutLogEventsInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.PutLogEventsOutput, error)
+}
+
+// NewCloudwatchCoreV2 is the aws-sdk-go-v2 counterpart of NewCloudwatchCore.
+// It threads params.Context through every CloudWatch Logs call. params.Client
+// is ignored; set params.ClientV2 to override the client it talks to.
+func NewCloudwatchCoreV2(params *NewCloudwatchCoreParams) (zapcore.Core, error) {
+	ctx := params.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client := params.ClientV2
+	if client == nil {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("zapcloudwatchcore: loading aws-sdk-go-v2 config: %w", err)
+		}
+		client = cloudwatchlogsv2.NewFromConfig(cfg)
+	}
+
+	core, err := newCore(params, &v2Adapter{ctx: ctx, client: client})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := core.cloudWatchInit(); err != nil {
+		return nil, err
+	}
+
+	go core.flushLoop()
+	go core.asyncWorker()
+
+	return core, nil
+}
+
+// v2Adapter implements the v1-shaped CloudWatchLogsAPI on top of an
+// aws-sdk-go-v2 client.
+type v2Adapter struct {
+	ctx    context.Context
+	client CloudWatchLogsAPIv2
+}
+
+func (a *v2Adapter) DescribeLogGroups(in *cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	var limit *int32
+	if in.Limit != nil {
+		limit = awsv2.Int32(int32(*in.Limit))
+	}
+
+	out, err := a.client.DescribeLogGroups(a.ctx, &cloudwatchlogsv2.DescribeLogGroupsInput{
+		LogGroupNamePrefix: in.LogGroupNamePrefix,
+		Limit:              limit,
+	})
+	if err != nil {
+		return nil, asV1Error(err)
+	}
+
+	v1out := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	for _, g := range out.LogGroups {
+		v1out.LogGroups = append(v1out.LogGroups, &cloudwatchlogs.LogGroup{LogGroupName: g.LogGroupName})
+	}
+	return v1out, nil
+}
+
+func (a *v2Adapter) CreateLogGroup(in *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	_, err := a.client.CreateLogGroup(a.ctx, &cloudwatchlogsv2.CreateLogGroupInput{
+		LogGroupName: in.LogGroupName,
+	})
+	if err != nil {
+		return nil, asV1Error(err)
+	}
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (a *v2Adapter) DescribeLogStreams(in *cloudwatchlogs.DescribeLogStreamsInput) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	out, err := a.client.DescribeLogStreams(a.ctx, &cloudwatchlogsv2.DescribeLogStreamsInput{
+		LogGroupName:        in.LogGroupName,
+		LogStreamNamePrefix: in.LogStreamNamePrefix,
+	})
+	if err != nil {
+		return nil, asV1Error(err)
+	}
+
+	v1out := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	for _, s := range out.LogStreams {
+		v1out.LogStreams = append(v1out.LogStreams, &cloudwatchlogs.LogStream{
+			LogStreamName:       s.LogStreamName,
+			UploadSequenceToken: s.UploadSequenceToken,
+		})
+	}
+	return v1out, nil
+}
+
+func (a *v2Adapter) CreateLogStream(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	_, err := a.client.CreateLogStream(a.ctx, &cloudwatchlogsv2.CreateLogStreamInput{
+		LogGroupName:  in.LogGroupName,
+		LogStreamName: in.LogStreamName,
+	})
+	if err != nil {
+		return nil, asV1Error(err)
+	}
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (a *v2Adapter) PutLogEvents(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	events := make([]typesv2.InputLogEvent, len(in.LogEvents))
+	for i, e := range in.LogEvents {
+		events[i] = typesv2.InputLogEvent{
+			Message:   e.Message,
+			Timestamp: e.Timestamp,
+		}
+	}
+
+	out, err := a.client.PutLogEvents(a.ctx, &cloudwatchlogsv2.PutLogEventsInput{
+		LogEvents:     events,
+		LogGroupName:  in.LogGroupName,
+		LogStreamName: in.LogStreamName,
+		SequenceToken: in.SequenceToken,
+	})
+	if err != nil {
+		return nil, asV1Error(err)
+	}
+
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: out.NextSequenceToken}, nil
+}
+
+// asV1Error rewraps a v2 smithy API error as a v1 awserr.Error carrying the
+// same code and message, so isResourceAlreadyExists and
+// expectedSequenceToken work unchanged regardless of SDK generation.
+func asV1Error(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return awserr.New(apiErr.ErrorCode(), apiErr.ErrorMessage(), err)
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package zapcloudwatchcore_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	cloudwatchlogsv2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	typesv2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+
+	"github.com/vmlellis/zapcloudwatchcore"
+)
+
+var _ zapcloudwatchcore.CloudWatchLogsAPIv2 = (*fakeV2Client)(nil)
+
+// fakeV2Client is a minimal in-memory aws-sdk-go-v2 CloudWatch Logs client,
+// covering just enough of CloudWatchLogsAPIv2 to exercise NewCloudwatchCoreV2
+// and the v2Adapter translation layer end to end.
+type fakeV2Client struct {
+	mu                sync.Mutex
+	groupCreated      bool
+	streamCreated     bool
+	nextSequenceToken *string
+	events            []typesv2.InputLogEvent
+}
+
+func (f *fakeV2Client) DescribeLogGroups(ctx context.Context, in *cloudwatchlogsv2.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.DescribeLogGroupsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &cloudwatchlogsv2.DescribeLogGroupsOutput{}
+	if f.groupCreated {
+		out.LogGroups = []typesv2.LogGroup{{LogGroupName: in.LogGroupNamePrefix}}
+	}
+	return out, nil
+}
+
+func (f *fakeV2Client) CreateLogGroup(ctx context.Context, in *cloudwatchlogsv2.CreateLogGroupInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.CreateLogGroupOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.groupCreated = true
+	return &cloudwatchlogsv2.CreateLogGroupOutput{}, nil
+}
+
+func (f *fakeV2Client) DescribeLogStreams(ctx context.Context, in *cloudwatchlogsv2.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.DescribeLogStreamsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &cloudwatchlogsv2.DescribeLogStreamsOutput{}
+	if f.streamCreated {
+		out.LogStreams = []typesv2.LogStream{{
+			LogStreamName:       in.LogStreamNamePrefix,
+			UploadSequenceToken: f.nextSequenceToken,
+		}}
+	}
+	return out, nil
+}
+
+func (f *fakeV2Client) CreateLogStream(ctx context.Context, in *cloudwatchlogsv2.CreateLogStreamInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.CreateLogStreamOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.streamCreated = true
+	return &cloudwatchlogsv2.CreateLogStreamOutput{}, nil
+}
+
+func (f *fakeV2Client) PutLogEvents(ctx context.Context, in *cloudwatchlogsv2.PutLogEventsInput, optFns ...func(*cloudwatchlogsv2.Options)) (*cloudwatchlogsv2.PutLogEventsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.events = append(f.events, in.LogEvents...)
+	token := fmt.Sprintf("%d", len(f.events))
+	f.nextSequenceToken = &token
+	return &cloudwatchlogsv2.PutLogEventsOutput{NextSequenceToken: &token}, nil
+}
+
+func TestNewCloudwatchCoreV2WritesThroughV2Client(t *testing.T) {
+	client := &fakeV2Client{}
+
+	core, err := zapcloudwatchcore.NewCloudwatchCoreV2(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:  "group",
+		StreamName: "stream",
+		ClientV2:   client,
+		Context:    context.Background(),
+		Enc:        zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:        zapcore.AddSync(new(strings.Builder)),
+		BatchSize:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCoreV2: %v", err)
+	}
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.events) != 1 {
+		t.Fatalf("got %d events, want 1", len(client.events))
+	}
+	if msg := *client.events[0].Message; !strings.Contains(msg, "hello") {
+		t.Fatalf("event message %q does not contain %q", msg, "hello")
+	}
+}
@@ -1,28 +1,144 @@
 package zapcloudwatchcore
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// perEventBytes is the per-message overhead CloudWatch Logs adds when
+	// accounting towards maximumBytesPerPut, as documented for PutLogEvents.
+	perEventBytes = 26
+	// maximumBytesPerPut is the hard limit CloudWatch Logs enforces on the
+	// total size of a single PutLogEvents batch.
+	maximumBytesPerPut = 1048576
+	// maximumBytesPerEvent is the hard limit on a single event's message,
+	// after accounting for perEventBytes.
+	maximumBytesPerEvent = 262144 - perEventBytes
+	// maximumLogEventsPerPut is the hard limit CloudWatch Logs enforces on
+	// the number of events in a single PutLogEvents batch.
+	maximumLogEventsPerPut = 10000
+	// defaultBatchPublishFrequency is how often the batcher flushes the
+	// pending buffer even if it hasn't filled up.
+	defaultBatchPublishFrequency = 5 * time.Second
+	// defaultMaxQueuedBatches bounds the number of flushes the Sync/flush
+	// loop will buffer up before applying backpressure.
+	defaultMaxQueuedBatches = 8
+
+	maxRetries        = 5
+	baseRetryInterval = 200 * time.Millisecond
+)
+
+// coreMetrics counts events across every stream and every clone of a
+// CloudwatchCore produced by With, so callers can monitor log loss instead
+// of it failing silently.
+type coreMetrics struct {
+	droppedEvents int64 // atomic
+	sentEvents    int64 // atomic
+	retryCount    int64 // atomic
+}
+
+// ErrQueueFull is reported through OnError when Async is true and a
+// stream's flush can't be handed to the async worker because
+// AsyncQueueDepth has been reached.
+var ErrQueueFull = errors.New("zapcloudwatchcore: async queue full, dropping batch")
+
+// CloudWatchLogsAPI is the subset of the aws-sdk-go CloudWatchLogs client
+// CloudwatchCore depends on, so callers can substitute a fake (see
+// zapcloudwatchcoretest) in tests.
+type CloudWatchLogsAPI interface {
+	DescribeLogGroups(*cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error)
+	CreateLogGroup(*cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	DescribeLogStreams(*cloudwatchlogs.DescribeLogStreamsInput) (*cloudwatchlogs.DescribeLogStreamsOutput, error)
+	CreateLogStream(*cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutLogEvents(*cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+// streamState holds everything specific to a single CloudWatch Logs stream:
+// its sequence token and the batch of events waiting to be flushed to it.
+// CloudwatchCore keeps one of these per resolved stream name so a single
+// core can fan events out to many streams within its log group.
+type streamState struct {
+	name string
+
+	mu                sync.Mutex // guards nextSequenceToken/pending/pendingBytes
+	nextSequenceToken *string
+	pending           []*cloudwatchlogs.InputLogEvent
+	pendingBytes      int
+
+	// flushMu serializes flushStream calls against this stream, so two
+	// concurrent flushes (two Write calls racing in sync mode, or two
+	// asyncWorker-spawned goroutines) never send overlapping PutLogEvents
+	// calls for the same stream and race on nextSequenceToken.
+	flushMu sync.Mutex
+}
+
 // CloudwatchCore is a zap Core for dispatching messages to the specified
+// CloudWatch Logs log group. Events are buffered per-stream and flushed in
+// batches, either when a stream's batch fills up or on a timer, to stay
+// within the PutLogEvents throttling limits.
 type CloudwatchCore struct {
 	// Messages with a log level not contained in this array
 	// will not be dispatched. If nil, all messages will be dispatched.
-	AcceptedLevels    []zapcore.Level
-	GroupName         string
-	StreamName        string
-	AWSConfig         *aws.Config
-	nextSequenceToken *string
-	svc               *cloudwatchlogs.CloudWatchLogs
-	Async             bool // if async is true, send a message asynchronously.
-	m                 sync.Mutex
+	AcceptedLevels []zapcore.Level
+	GroupName      string
+	StreamName     string
+	AWSConfig      *aws.Config
+	svc            CloudWatchLogsAPI
+	Async          bool // if async is true, send a message asynchronously.
+
+	// streamNameTmpl, when set, is evaluated against each entry (plus its
+	// accumulated With fields) to pick which stream within GroupName the
+	// entry is batched onto, instead of always using StreamName.
+	streamNameTmpl *template.Template
+
+	// messageFormat controls how entries are re-encoded before being sent
+	// to CloudWatch Logs. See MessageFormat.
+	messageFormat MessageFormat
+
+	batchSize        int
+	flushInterval    time.Duration
+	maxQueuedBatches int
+
+	// streamsMu is a pointer, not a value, because it (and the streams map
+	// it guards) are shared by reference across every clone produced by
+	// With; a per-clone mutex would let concurrent clones race on the same
+	// map.
+	streamsMu *sync.Mutex
+	streams   map[string]*streamState
+
+	accumFieldsMu sync.Mutex
+	accumFields   map[string]interface{}
+
+	flushSem chan struct{} // bounds concurrent in-flight flushes
+
+	// onError, when set, is invoked with every event that couldn't be
+	// delivered: a PutLogEvents failure that exhausted its retries, or an
+	// asyncQueue that's full.
+	onError    func(err error, dropped []*cloudwatchlogs.InputLogEvent)
+	asyncQueue chan *streamState
+	metrics    *coreMetrics // shared across clones produced by With
+
+	// stopCh is closed by Close to stop flushLoop and asyncWorker. It (and
+	// closeOnce, guarding against closing it twice) are shared across every
+	// clone produced by With, since all clones share the one pair of
+	// background goroutines started by NewCloudwatchCore/NewCloudwatchCoreV2.
+	stopCh    chan struct{}
+	closeOnce *sync.Once
 
 	zapcore.LevelEnabler
 	enc zapcore.Encoder
@@ -42,44 +158,200 @@ type NewCloudwatchCoreParams struct {
 	Enc          zapcore.Encoder
 	Out          zapcore.WriteSyncer
 	LevelEnabler zapcore.LevelEnabler
+
+	// BatchSize caps the number of events flushed in a single
+	// PutLogEvents call. Defaults to maximumLogEventsPerPut.
+	BatchSize int
+	// FlushInterval is how often each stream's pending buffer is flushed
+	// even if BatchSize hasn't been reached. Defaults to 5s, matching the
+	// Docker awslogs driver's batchPublishFrequency.
+	FlushInterval time.Duration
+	// MaxQueuedBatches bounds the number of flushes allowed to be
+	// in-flight concurrently before callers block. Defaults to 8.
+	MaxQueuedBatches int
+
+	// OnError, when set, is invoked whenever events are dropped: a
+	// PutLogEvents call that exhausted its retries, or (in Async mode) a
+	// full async queue. dropped holds exactly the events that were lost.
+	OnError func(err error, dropped []*cloudwatchlogs.InputLogEvent)
+	// AsyncQueueDepth bounds how many stream flushes may be queued for the
+	// async worker before QueueFull errors start being reported through
+	// OnError instead of growing memory without bound. Only relevant when
+	// IsAsync is true. Defaults to 4x MaxQueuedBatches.
+	AsyncQueueDepth int
+
+	// StreamNameTemplate, when set, is a text/template string evaluated
+	// against each entry to resolve the stream it should be written to,
+	// e.g. `{{.Level}}/{{.LoggerName}}/{{index .Fields "request_id"}}`.
+	// Streams are created lazily the first time they're resolved. When
+	// empty, every entry goes to StreamName.
+	StreamNameTemplate string
+
+	// Client overrides the CloudWatch Logs client CloudwatchCore talks to.
+	// When nil, one is built from Config via session.New, as before. Tests
+	// can pass a fake implementing CloudWatchLogsAPI instead of hitting AWS.
+	Client CloudWatchLogsAPI
+
+	// Context bounds every CloudWatch Logs API call made by
+	// NewCloudwatchCoreV2, so canceling it aborts in-flight puts. It is
+	// ignored by NewCloudwatchCore, whose v1 calls take no context.
+	// Defaults to context.Background().
+	Context context.Context
+
+	// ClientV2 overrides the v2 CloudWatch Logs client NewCloudwatchCoreV2
+	// talks to. When nil, one is built from Context via
+	// config.LoadDefaultConfig.
+	ClientV2 CloudWatchLogsAPIv2
+
+	// MessageFormat controls how entries are re-encoded before being sent
+	// to CloudWatch Logs. Defaults to FormatEncoder, i.e. Enc's own
+	// output. See MessageFormat.
+	MessageFormat MessageFormat
 }
 
 func NewCloudwatchCore(params *NewCloudwatchCoreParams) (zapcore.Core, error) {
-	core := &CloudwatchCore{
-		GroupName:      params.GroupName,
-		StreamName:     params.StreamName,
-		AWSConfig:      params.Config,
-		Async:          params.IsAsync,
-		AcceptedLevels: LevelThreshold(params.Level),
-		LevelEnabler:   params.LevelEnabler,
-		enc:            params.Enc,
-		out:            params.Out,
-	}
-
-	err := core.cloudWatchInit()
+	core, err := newCore(params, params.Client)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := core.cloudWatchInit(); err != nil {
+		return nil, err
+	}
+
+	go core.flushLoop()
+	go core.asyncWorker()
+
 	return core, nil
 }
 
+// newCore builds a CloudwatchCore from params around the given client,
+// without performing AWS calls or starting its background flush loop. It
+// is the shared construction path for both NewCloudwatchCore (v1) and
+// NewCloudwatchCoreV2.
+func newCore(params *NewCloudwatchCoreParams, svc CloudWatchLogsAPI) (*CloudwatchCore, error) {
+	batchSize := params.BatchSize
+	if batchSize <= 0 || batchSize > maximumLogEventsPerPut {
+		batchSize = maximumLogEventsPerPut
+	}
+	flushInterval := params.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultBatchPublishFrequency
+	}
+	maxQueuedBatches := params.MaxQueuedBatches
+	if maxQueuedBatches <= 0 {
+		maxQueuedBatches = defaultMaxQueuedBatches
+	}
+	asyncQueueDepth := params.AsyncQueueDepth
+	if asyncQueueDepth <= 0 {
+		asyncQueueDepth = 4 * maxQueuedBatches
+	}
+
+	var streamNameTmpl *template.Template
+	if params.StreamNameTemplate != "" {
+		tmpl, err := template.New("streamName").Parse(params.StreamNameTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("zapcloudwatchcore: invalid StreamNameTemplate: %w", err)
+		}
+		streamNameTmpl = tmpl
+	}
+
+	return &CloudwatchCore{
+		GroupName:        params.GroupName,
+		StreamName:       params.StreamName,
+		AWSConfig:        params.Config,
+		Async:            params.IsAsync,
+		AcceptedLevels:   LevelThreshold(params.Level),
+		LevelEnabler:     params.LevelEnabler,
+		enc:              params.Enc,
+		out:              params.Out,
+		streamNameTmpl:   streamNameTmpl,
+		messageFormat:    params.MessageFormat,
+		batchSize:        batchSize,
+		flushInterval:    flushInterval,
+		maxQueuedBatches: maxQueuedBatches,
+		streamsMu:        new(sync.Mutex),
+		streams:          make(map[string]*streamState),
+		accumFields:      make(map[string]interface{}),
+		flushSem:         make(chan struct{}, maxQueuedBatches),
+		onError:          params.OnError,
+		asyncQueue:       make(chan *streamState, asyncQueueDepth),
+		metrics:          &coreMetrics{},
+		stopCh:           make(chan struct{}),
+		closeOnce:        new(sync.Once),
+		svc:              svc,
+	}, nil
+}
+
 func (c *CloudwatchCore) With(fields []zapcore.Field) zapcore.Core {
 	clone := c.clone()
 	addFields(clone.enc, fields)
+
+	clone.accumFieldsMu.Lock()
+	clone.accumFields = mergedFields(clone.accumFields, fields)
+	clone.accumFieldsMu.Unlock()
+
 	return clone
 }
 
 func (c *CloudwatchCore) clone() *CloudwatchCore {
+	c.accumFieldsMu.Lock()
+	accumFields := mergedFields(nil, nil)
+	for k, v := range c.accumFields {
+		accumFields[k] = v
+	}
+	c.accumFieldsMu.Unlock()
+
 	return &CloudwatchCore{
-		GroupName:      c.GroupName,
-		StreamName:     c.StreamName,
-		AWSConfig:      c.AWSConfig,
-		Async:          c.Async,
-		AcceptedLevels: c.AcceptedLevels,
-		LevelEnabler:   c.LevelEnabler,
-		enc:            c.enc.Clone(),
-		out:            c.out,
+		GroupName:        c.GroupName,
+		StreamName:       c.StreamName,
+		AWSConfig:        c.AWSConfig,
+		Async:            c.Async,
+		AcceptedLevels:   c.AcceptedLevels,
+		LevelEnabler:     c.LevelEnabler,
+		enc:              c.enc.Clone(),
+		out:              c.out,
+		svc:              c.svc,
+		streamNameTmpl:   c.streamNameTmpl,
+		messageFormat:    c.messageFormat,
+		batchSize:        c.batchSize,
+		flushInterval:    c.flushInterval,
+		maxQueuedBatches: c.maxQueuedBatches,
+		streamsMu:        c.streamsMu,
+		streams:          c.streams,
+		accumFields:      accumFields,
+		flushSem:         c.flushSem,
+		onError:          c.onError,
+		asyncQueue:       c.asyncQueue,
+		metrics:          c.metrics,
+		stopCh:           c.stopCh,
+		closeOnce:        c.closeOnce,
+	}
+}
+
+// DroppedEvents returns the number of events lost to a failed PutLogEvents
+// call or a full async queue.
+func (c *CloudwatchCore) DroppedEvents() int64 {
+	return atomic.LoadInt64(&c.metrics.droppedEvents)
+}
+
+// SentEvents returns the number of events successfully delivered via
+// PutLogEvents.
+func (c *CloudwatchCore) SentEvents() int64 {
+	return atomic.LoadInt64(&c.metrics.sentEvents)
+}
+
+// RetryCount returns the number of PutLogEvents attempts that failed and
+// were retried.
+func (c *CloudwatchCore) RetryCount() int64 {
+	return atomic.LoadInt64(&c.metrics.retryCount)
+}
+
+// reportError invokes onError, if set, with the events that were dropped.
+func (c *CloudwatchCore) reportError(err error, dropped []*cloudwatchlogs.InputLogEvent) {
+	atomic.AddInt64(&c.metrics.droppedEvents, int64(len(dropped)))
+	if c.onError != nil {
+		c.onError(err, dropped)
 	}
 }
 
@@ -89,6 +361,37 @@ func addFields(enc zapcore.ObjectEncoder, fields []zapcore.Field) {
 	}
 }
 
+// mergedFields returns a new map containing base overlaid with fields,
+// converting each zapcore.Field to a plain value suitable for use from a
+// StreamNameTemplate.
+func mergedFields(base map[string]interface{}, fields []zapcore.Field) map[string]interface{} {
+	out := make(map[string]interface{}, len(base)+len(fields))
+	for k, v := range base {
+		out[k] = v
+	}
+	for _, f := range fields {
+		out[f.Key] = fieldValue(f)
+	}
+	return out
+}
+
+func fieldValue(f zapcore.Field) interface{} {
+	switch f.Type {
+	case zapcore.StringType:
+		return f.String
+	case zapcore.BoolType:
+		return f.Integer != 0
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		return f.Integer
+	default:
+		if f.Interface != nil {
+			return f.Interface
+		}
+		return f.String
+	}
+}
+
 func (c *CloudwatchCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
 	if c.Enabled(ent.Level) {
 		return ce.AddCore(ent, c)
@@ -97,14 +400,12 @@ func (c *CloudwatchCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zap
 }
 
 func (c *CloudwatchCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
-	buf, err := c.enc.EncodeEntry(ent, fields)
+	msg, err := c.encodeMessage(ent, fields)
 	if err != nil {
 		return err
 	}
-	err = c.cloudwatchWriter(ent, buf.String())
-	buf.Free()
 
-	if err != nil {
+	if err := c.cloudwatchWriter(ent, fields, msg); err != nil {
 		return err
 	}
 
@@ -117,37 +418,390 @@ func (c *CloudwatchCore) Write(ent zapcore.Entry, fields []zapcore.Field) error
 	return nil
 }
 
+// Sync flushes every stream's pending buffer and blocks until they have
+// been sent. It flushes every stream unconditionally, collecting errors
+// rather than bailing out on the first one, since Write calls Sync on
+// Fatal/Panic entries right before the process typically exits and a
+// single throttled stream should not cause every other stream's buffered
+// events to be silently discarded.
 func (c *CloudwatchCore) Sync() error {
-	return c.out.Sync()
+	c.streamsMu.Lock()
+	states := make([]*streamState, 0, len(c.streams))
+	for _, s := range c.streams {
+		states = append(states, s)
+	}
+	c.streamsMu.Unlock()
+
+	var errs []error
+	for _, s := range states {
+		if err := c.flushStream(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := c.out.Sync(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
-func (c *CloudwatchCore) cloudwatchWriter(e zapcore.Entry, msg string) error {
+// Close flushes every stream's pending buffer, then stops flushLoop and
+// asyncWorker so NewCloudwatchCore/NewCloudwatchCoreV2 don't leak them for
+// the life of the process. It is safe to call from any clone produced by
+// With, and safe to call more than once. Canceling params.Context (v2 only)
+// aborts in-flight PutLogEvents calls, but does not stop these loops on its
+// own; call Close to shut a core down cleanly.
+func (c *CloudwatchCore) Close() error {
+	err := c.Sync()
+	c.closeOnce.Do(func() { close(c.stopCh) })
+	return err
+}
+
+// streamNameTemplateData is the value a StreamNameTemplate is executed
+// against.
+type streamNameTemplateData struct {
+	Level      string
+	LoggerName string
+	Fields     map[string]interface{}
+}
+
+// resolveStreamName picks the stream an entry should be batched onto,
+// evaluating StreamNameTemplate against the entry plus its accumulated
+// With fields when one is configured.
+func (c *CloudwatchCore) resolveStreamName(ent zapcore.Entry, fields []zapcore.Field) (string, error) {
+	if c.streamNameTmpl == nil {
+		return c.StreamName, nil
+	}
+
+	c.accumFieldsMu.Lock()
+	data := streamNameTemplateData{
+		Level:      ent.Level.String(),
+		LoggerName: ent.LoggerName,
+		Fields:     mergedFields(c.accumFields, fields),
+	}
+	c.accumFieldsMu.Unlock()
+
+	var buf bytes.Buffer
+	if err := c.streamNameTmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("zapcloudwatchcore: StreamNameTemplate: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// streamFor returns the streamState for name, creating the CloudWatch Logs
+// stream (and local state) lazily on first use.
+func (c *CloudwatchCore) streamFor(name string) (*streamState, error) {
+	c.streamsMu.Lock()
+	s, ok := c.streams[name]
+	c.streamsMu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	_, err := c.svc.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(c.GroupName),
+		LogStreamName: aws.String(name),
+	})
+	if err != nil && !isResourceAlreadyExists(err) {
+		return nil, err
+	}
+
+	c.streamsMu.Lock()
+	defer c.streamsMu.Unlock()
+	if s, ok := c.streams[name]; ok {
+		return s, nil
+	}
+	s = &streamState{name: name}
+	c.streams[name] = s
+	return s, nil
+}
+
+// cloudwatchWriter buffers msg for the given entry on its resolved stream,
+// splitting it if it exceeds maximumBytesPerEvent, and flushes that
+// stream's buffer once it holds at least BatchSize events or
+// maximumBytesPerPut bytes.
+func (c *CloudwatchCore) cloudwatchWriter(e zapcore.Entry, fields []zapcore.Field, msg string) error {
 	if !c.isAcceptedLevel(e.Level) {
 		return nil
 	}
 
-	event := &cloudwatchlogs.InputLogEvent{
-		Message:   aws.String(fmt.Sprintf("%s", msg)),
-		Timestamp: aws.Int64(int64(time.Nanosecond) * time.Now().UnixNano() / int64(time.Millisecond)),
+	name, err := c.resolveStreamName(e, fields)
+	if err != nil {
+		return err
 	}
-	params := &cloudwatchlogs.PutLogEventsInput{
-		LogEvents:     []*cloudwatchlogs.InputLogEvent{event},
-		LogGroupName:  aws.String(c.GroupName),
-		LogStreamName: aws.String(c.StreamName),
-		SequenceToken: c.nextSequenceToken,
+
+	s, err := c.streamFor(name)
+	if err != nil {
+		return err
+	}
+
+	ts := aws.Int64(e.Time.UnixNano() / int64(time.Millisecond))
+
+	shouldFlush := false
+	s.mu.Lock()
+	for _, chunk := range splitMessage(msg, maximumBytesPerEvent) {
+		event := &cloudwatchlogs.InputLogEvent{
+			Message:   aws.String(chunk),
+			Timestamp: ts,
+		}
+		s.pending = append(s.pending, event)
+		s.pendingBytes += len(chunk) + perEventBytes
+
+		if len(s.pending) >= c.batchSize || s.pendingBytes >= maximumBytesPerPut {
+			shouldFlush = true
+		}
+	}
+	s.mu.Unlock()
+
+	if !shouldFlush {
+		return nil
 	}
 
 	if c.Async {
-		go c.sendEvent(params)
+		c.enqueueFlush(s)
 		return nil
 	}
 
-	return c.sendEvent(params)
+	return c.flushStream(s)
+}
+
+// enqueueFlush hands s to the async worker. If the queue is already full,
+// the batch currently sitting in s.pending is dropped and reported via
+// OnError.
+func (c *CloudwatchCore) enqueueFlush(s *streamState) {
+	select {
+	case c.asyncQueue <- s:
+	default:
+		s.mu.Lock()
+		dropped := s.pending
+		s.pending = nil
+		s.pendingBytes = 0
+		s.mu.Unlock()
+
+		if len(dropped) > 0 {
+			c.reportError(ErrQueueFull, dropped)
+		}
+	}
+}
+
+// asyncWorker dispatches async flushes picked up from asyncQueue; flushSem
+// still bounds how many of them are actually in flight against AWS at once.
+// It exits once Close is called.
+func (c *CloudwatchCore) asyncWorker() {
+	for {
+		select {
+		case s := <-c.asyncQueue:
+			go c.flushStream(s)
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// splitMessage breaks msg into chunks no larger than maxBytes.
+func splitMessage(msg string, maxBytes int) []string {
+	if len(msg) <= maxBytes {
+		return []string{msg}
+	}
+
+	var chunks []string
+	for len(msg) > maxBytes {
+		chunks = append(chunks, msg[:maxBytes])
+		msg = msg[maxBytes:]
+	}
+	if len(msg) > 0 {
+		chunks = append(chunks, msg)
+	}
+	return chunks
+}
+
+// flushLoop flushes every stream's pending buffer every flushInterval, so
+// logs are eventually delivered even if a batch never fills up. It exits
+// once Close is called.
+func (c *CloudwatchCore) flushLoop() {
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.streamsMu.Lock()
+			states := make([]*streamState, 0, len(c.streams))
+			for _, s := range c.streams {
+				states = append(states, s)
+			}
+			c.streamsMu.Unlock()
+
+			for _, s := range states {
+				c.flushStream(s)
+			}
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// flushStream sends whatever is currently pending on s, sorted by
+// timestamp ascending as PutLogEvents requires, in PutLogEvents calls of at
+// most BatchSize events. A failed call is retried with exponential backoff,
+// except that a sequence token error is retried at once with the token
+// CloudWatch reports. It serializes on
+// s.flushMu so concurrent flushes of the same stream (two racing Write
+// calls in sync mode, or two asyncWorker-spawned goroutines) send one at a
+// time instead of racing over nextSequenceToken and burning retries on
+// self-inflicted collisions.
+func (c *CloudwatchCore) flushStream(s *streamState) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	events := s.pending
+	s.pending = nil
+	s.pendingBytes = 0
+	s.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		return aws.Int64Value(events[i].Timestamp) < aws.Int64Value(events[j].Timestamp)
+	})
+
+	c.flushSem <- struct{}{}
+	defer func() { <-c.flushSem }()
+
+	var errs []error
+	for len(events) > 0 {
+		n := len(events)
+		if n > c.batchSize {
+			n = c.batchSize
+		}
+		if err := c.putLogEventsWithRetry(s, events[:n]); err != nil {
+			errs = append(errs, err)
+		}
+		events = events[n:]
+	}
+	return errors.Join(errs...)
+}
+
+func (c *CloudwatchCore) putLogEventsWithRetry(s *streamState, events []*cloudwatchlogs.InputLogEvent) error {
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		s.mu.Lock()
+		token := s.nextSequenceToken
+		s.mu.Unlock()
+
+		params := &cloudwatchlogs.PutLogEventsInput{
+			LogEvents:     events,
+			LogGroupName:  aws.String(c.GroupName),
+			LogStreamName: aws.String(s.name),
+			SequenceToken: token,
+		}
+
+		resp, err := c.svc.PutLogEvents(params)
+		if err == nil {
+			s.mu.Lock()
+			s.nextSequenceToken = resp.NextSequenceToken
+			s.mu.Unlock()
+			atomic.AddInt64(&c.metrics.sentEvents, int64(len(events)))
+			return nil
+		}
+
+		lastErr = err
+
+		switch expected, action := expectedSequenceToken(err); action {
+		case acceptedSequenceToken:
+			// CloudWatch already durably stored this batch; adopt the
+			// token it reports but don't resend data it already has.
+			s.mu.Lock()
+			s.nextSequenceToken = expected
+			s.mu.Unlock()
+			atomic.AddInt64(&c.metrics.sentEvents, int64(len(events)))
+			return nil
+		case retrySequenceToken:
+			if attempt < maxRetries {
+				atomic.AddInt64(&c.metrics.retryCount, 1)
+			}
+			s.mu.Lock()
+			s.nextSequenceToken = expected
+			s.mu.Unlock()
+			continue
+		}
+
+		if attempt == maxRetries {
+			break
+		}
+		atomic.AddInt64(&c.metrics.retryCount, 1)
+		time.Sleep(backoffDuration(attempt))
+	}
+
+	err := fmt.Errorf("zapcloudwatchcore: PutLogEvents to stream %q failed after %d attempts: %w", s.name, maxRetries+1, lastErr)
+	c.reportError(err, events)
+	return err
+}
+
+func backoffDuration(attempt int) time.Duration {
+	d := baseRetryInterval
+	for i := 0; i < attempt; i++ {
+		d *= 2
+	}
+	return d
+}
+
+var (
+	invalidSequenceTokenPattern = regexp.MustCompile(`sequenceToken is: (\S+)`)
+	dataAlreadyAcceptedPattern  = regexp.MustCompile(`sent with sequenceToken: (\S+)`)
+)
+
+// sequenceTokenAction tells putLogEventsWithRetry how to react to a
+// PutLogEvents error that carries CloudWatch's expected next sequence
+// token.
+type sequenceTokenAction int
+
+const (
+	// noSequenceTokenAction means err isn't a sequence-token error.
+	noSequenceTokenAction sequenceTokenAction = iota
+	// retrySequenceToken means the send failed outright; adopt the token
+	// and resend the same batch.
+	retrySequenceToken
+	// acceptedSequenceToken means CloudWatch already durably stored this
+	// batch; adopt the token but the batch itself must not be resent.
+	acceptedSequenceToken
+)
+
+// expectedSequenceToken extracts the sequence token CloudWatch Logs reports
+// as expected from an InvalidSequenceTokenException or
+// DataAlreadyAcceptedException. The two exceptions word their message
+// differently and mean different things: an invalid token means the send
+// failed and should be retried with the correct token, while "data already
+// accepted" means the send already succeeded and must not be repeated.
+func expectedSequenceToken(err error) (*string, sequenceTokenAction) {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return nil, noSequenceTokenAction
+	}
+
+	switch aerr.Code() {
+	case cloudwatchlogs.ErrCodeInvalidSequenceTokenException:
+		if m := invalidSequenceTokenPattern.FindStringSubmatch(aerr.Message()); m != nil {
+			return aws.String(m[1]), retrySequenceToken
+		}
+		return nil, retrySequenceToken
+	case cloudwatchlogs.ErrCodeDataAlreadyAcceptedException:
+		if m := dataAlreadyAcceptedPattern.FindStringSubmatch(aerr.Message()); m != nil {
+			return aws.String(m[1]), acceptedSequenceToken
+		}
+		return nil, acceptedSequenceToken
+	default:
+		return nil, noSequenceTokenAction
+	}
 }
 
 // GetHook function returns hook to zap
 func (c *CloudwatchCore) cloudWatchInit() error {
-	c.svc = cloudwatchlogs.New(session.New(c.AWSConfig))
+	if c.svc == nil {
+		c.svc = cloudwatchlogs.New(session.New(c.AWSConfig))
+	}
 
 	lgresp, err := c.svc.DescribeLogGroups(&cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: aws.String(c.GroupName), Limit: aws.Int64(1)})
 	if err != nil {
@@ -157,11 +811,18 @@ func (c *CloudwatchCore) cloudWatchInit() error {
 	if len(lgresp.LogGroups) < 1 {
 		// we need to create this log group
 		_, err := c.svc.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(c.GroupName)})
-		if err != nil {
+		if err != nil && !isResourceAlreadyExists(err) {
 			return err
 		}
 	}
 
+	// When StreamNameTemplate is set, streams are resolved per-entry and
+	// created lazily via streamFor; there is no single default stream to
+	// initialize here, and StreamName may legitimately be empty.
+	if c.streamNameTmpl != nil {
+		return nil
+	}
+
 	resp, err := c.svc.DescribeLogStreams(&cloudwatchlogs.DescribeLogStreamsInput{
 		LogGroupName:        aws.String(c.GroupName), // Required
 		LogStreamNamePrefix: aws.String(c.StreamName),
@@ -170,9 +831,11 @@ func (c *CloudwatchCore) cloudWatchInit() error {
 		return err
 	}
 
+	s := &streamState{name: c.StreamName}
 	// grab the next sequence token
 	if len(resp.LogStreams) > 0 {
-		c.nextSequenceToken = resp.LogStreams[0].UploadSequenceToken
+		s.nextSequenceToken = resp.LogStreams[0].UploadSequenceToken
+		c.streams[c.StreamName] = s
 		return nil
 	}
 
@@ -182,22 +845,16 @@ func (c *CloudwatchCore) cloudWatchInit() error {
 		LogStreamName: aws.String(c.StreamName),
 	})
 
-	if err != nil {
+	if err != nil && !isResourceAlreadyExists(err) {
 		return err
 	}
+	c.streams[c.StreamName] = s
 	return nil
 }
 
-func (c *CloudwatchCore) sendEvent(params *cloudwatchlogs.PutLogEventsInput) error {
-	c.m.Lock()
-	defer c.m.Unlock()
-
-	resp, err := c.svc.PutLogEvents(params)
-	if err != nil {
-		return err
-	}
-	c.nextSequenceToken = resp.NextSequenceToken
-	return nil
+func isResourceAlreadyExists(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == cloudwatchlogs.ErrCodeResourceAlreadyExistsException
 }
 
 // Levels sets which levels to sent to cloudwatch
@@ -0,0 +1,367 @@
+package zapcloudwatchcore_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+
+	"github.com/vmlellis/zapcloudwatchcore"
+	"github.com/vmlellis/zapcloudwatchcore/zapcloudwatchcoretest"
+)
+
+func testEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		MessageKey:     "msg",
+		LevelKey:       "level",
+		TimeKey:        "time",
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	}
+}
+
+func newTestCore(t *testing.T, client *zapcloudwatchcoretest.Client) *zapcloudwatchcore.CloudwatchCore {
+	t.Helper()
+
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:  "group",
+		StreamName: "stream",
+		Client:     client,
+		Enc:        zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:        zapcore.AddSync(new(strings.Builder)),
+		BatchSize:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+
+	cwCore, ok := core.(*zapcloudwatchcore.CloudwatchCore)
+	if !ok {
+		t.Fatalf("NewCloudwatchCore returned %T, want *CloudwatchCore", core)
+	}
+	return cwCore
+}
+
+func TestWriteFlushesToClient(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core := newTestCore(t, client)
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	events := client.Events("group", "stream")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if msg := *events[0].Message; !strings.Contains(msg, "hello") {
+		t.Fatalf("event message %q does not contain %q", msg, "hello")
+	}
+}
+
+func TestRetryRecoversFromInvalidSequenceToken(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core := newTestCore(t, client)
+
+	client.RejectNextPutWithInvalidSequenceToken("group", "stream")
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	events := client.Events("group", "stream")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if got := core.RetryCount(); got != 1 {
+		t.Fatalf("RetryCount() = %d, want 1", got)
+	}
+}
+
+func TestDataAlreadyAcceptedDoesNotResend(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core := newTestCore(t, client)
+
+	client.RejectNextPutAsDataAlreadyAccepted("group", "stream")
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, nil); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	events := client.Events("group", "stream")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1 (no duplicate resend)", len(events))
+	}
+	if got := core.SentEvents(); got != 1 {
+		t.Fatalf("SentEvents() = %d, want 1", got)
+	}
+	if got := core.RetryCount(); got != 0 {
+		t.Fatalf("RetryCount() = %d, want 0 (already-accepted isn't a retry)", got)
+	}
+}
+
+func TestRetryExhaustionReportsOnErrorAndDropsEvents(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+
+	var (
+		mu      sync.Mutex
+		gotErr  error
+		dropped []*cloudwatchlogs.InputLogEvent
+	)
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:  "group",
+		StreamName: "stream",
+		Client:     client,
+		Enc:        zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:        zapcore.AddSync(new(strings.Builder)),
+		BatchSize:  1,
+		OnError: func(err error, events []*cloudwatchlogs.InputLogEvent) {
+			mu.Lock()
+			gotErr = err
+			dropped = events
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+	cwCore, ok := core.(*zapcloudwatchcore.CloudwatchCore)
+	if !ok {
+		t.Fatalf("NewCloudwatchCore returned %T, want *CloudwatchCore", core)
+	}
+
+	client.AlwaysRejectPutsWithInvalidSequenceToken("group", "stream")
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, nil); err == nil {
+		t.Fatal("Write: want error after retries are exhausted, got nil")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotErr == nil {
+		t.Fatal("OnError was not invoked")
+	}
+	if len(dropped) != 1 {
+		t.Fatalf("OnError dropped %d events, want 1", len(dropped))
+	}
+	if got := cwCore.DroppedEvents(); got != 1 {
+		t.Fatalf("DroppedEvents() = %d, want 1", got)
+	}
+}
+
+func TestStreamNameTemplateFansOutAcrossStreams(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:          "group",
+		StreamNameTemplate: "{{.Level}}",
+		Client:             client,
+		Enc:                zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:                zapcore.AddSync(new(strings.Builder)),
+		BatchSize:          1,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "info msg"}, nil); err != nil {
+		t.Fatalf("Write(info): %v", err)
+	}
+	if err := core.Write(zapcore.Entry{Level: zapcore.WarnLevel, Time: time.Unix(0, 0), Message: "warn msg"}, nil); err != nil {
+		t.Fatalf("Write(warn): %v", err)
+	}
+
+	infoEvents := client.Events("group", "info")
+	if len(infoEvents) != 1 {
+		t.Fatalf("got %d events on stream %q, want 1", len(infoEvents), "info")
+	}
+	if msg := *infoEvents[0].Message; !strings.Contains(msg, "info msg") {
+		t.Fatalf("event message %q does not contain %q", msg, "info msg")
+	}
+
+	warnEvents := client.Events("group", "warn")
+	if len(warnEvents) != 1 {
+		t.Fatalf("got %d events on stream %q, want 1", len(warnEvents), "warn")
+	}
+	if msg := *warnEvents[0].Message; !strings.Contains(msg, "warn msg") {
+		t.Fatalf("event message %q does not contain %q", msg, "warn msg")
+	}
+}
+
+func TestMessageFormatJSONNestsFieldsAndUsesEntryTimestamp(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:     "group",
+		StreamName:    "stream",
+		Client:        client,
+		Enc:           zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:           zapcore.AddSync(new(strings.Builder)),
+		BatchSize:     1,
+		MessageFormat: zapcloudwatchcore.FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+
+	entryTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fields := []zapcore.Field{{Key: "request_id", Type: zapcore.StringType, String: "abc"}}
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: entryTime, LoggerName: "svc", Message: "hello"}, fields); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	events := client.Events("group", "stream")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(*events[0].Message), &decoded); err != nil {
+		t.Fatalf("unmarshal message: %v", err)
+	}
+	if decoded["msg"] != "hello" {
+		t.Fatalf("decoded[msg] = %v, want %q", decoded["msg"], "hello")
+	}
+	if decoded["logger"] != "svc" {
+		t.Fatalf("decoded[logger] = %v, want %q", decoded["logger"], "svc")
+	}
+	nested, ok := decoded["fields"].(map[string]interface{})
+	if !ok || nested["request_id"] != "abc" {
+		t.Fatalf("decoded[fields] = %v, want a map containing request_id=abc", decoded["fields"])
+	}
+
+	wantTsMs := float64(entryTime.UnixNano() / int64(time.Millisecond))
+	if decoded["timestamp"] != wantTsMs {
+		t.Fatalf("decoded[timestamp] = %v, want %v", decoded["timestamp"], wantTsMs)
+	}
+	if got := *events[0].Timestamp; got != int64(wantTsMs) {
+		t.Fatalf("event Timestamp = %d, want %d (the entry's time, not time.Now)", got, int64(wantTsMs))
+	}
+}
+
+func TestMessageFormatJSONFlatMergesFieldsAtTopLevel(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:     "group",
+		StreamName:    "stream",
+		Client:        client,
+		Enc:           zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:           zapcore.AddSync(new(strings.Builder)),
+		BatchSize:     1,
+		MessageFormat: zapcloudwatchcore.FormatJSONFlat,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+
+	fields := []zapcore.Field{
+		{Key: "request_id", Type: zapcore.StringType, String: "abc"},
+		// "msg" collides with a stable top-level key and must lose to it.
+		{Key: "msg", Type: zapcore.StringType, String: "should not win"},
+	}
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, fields); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	events := client.Events("group", "stream")
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(*events[0].Message), &decoded); err != nil {
+		t.Fatalf("unmarshal message: %v", err)
+	}
+	if decoded["request_id"] != "abc" {
+		t.Fatalf("decoded[request_id] = %v, want %q", decoded["request_id"], "abc")
+	}
+	if decoded["msg"] != "hello" {
+		t.Fatalf("decoded[msg] = %v, want %q (the stable key, not the colliding field)", decoded["msg"], "hello")
+	}
+	if _, nested := decoded["fields"]; nested {
+		t.Fatalf("decoded has a nested %q key, want fields merged at the top level", "fields")
+	}
+}
+
+func TestConcurrentWithClonesShareStreamsSafely(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:          "group",
+		StreamNameTemplate: `{{index .Fields "request_id"}}`,
+		Client:             client,
+		Enc:                zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:                zapcore.AddSync(new(strings.Builder)),
+		BatchSize:          1,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			requestID := fmt.Sprintf("req-%d", i)
+			logger := core.With([]zapcore.Field{{Key: "request_id", Type: zapcore.StringType, String: requestID}})
+			if err := logger.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: "hello"}, nil); err != nil {
+				t.Errorf("Write: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < goroutines; i++ {
+		requestID := fmt.Sprintf("req-%d", i)
+		if events := client.Events("group", requestID); len(events) != 1 {
+			t.Fatalf("got %d events on stream %q, want 1", len(events), requestID)
+		}
+	}
+}
+
+func TestConcurrentFlushesOfSameStreamDoNotRaceOnSequenceToken(t *testing.T) {
+	client := zapcloudwatchcoretest.NewClient()
+	core, err := zapcloudwatchcore.NewCloudwatchCore(&zapcloudwatchcore.NewCloudwatchCoreParams{
+		GroupName:  "group",
+		StreamName: "stream",
+		Client:     client,
+		Enc:        zapcore.NewJSONEncoder(testEncoderConfig()),
+		Out:        zapcore.AddSync(new(strings.Builder)),
+		BatchSize:  1,
+	})
+	if err != nil {
+		t.Fatalf("NewCloudwatchCore: %v", err)
+	}
+	cwCore, ok := core.(*zapcloudwatchcore.CloudwatchCore)
+	if !ok {
+		t.Fatalf("NewCloudwatchCore returned %T, want *CloudwatchCore", core)
+	}
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			msg := fmt.Sprintf("msg-%d", i)
+			if err := cwCore.Write(zapcore.Entry{Level: zapcore.InfoLevel, Time: time.Unix(0, 0), Message: msg}, nil); err != nil {
+				t.Errorf("Write: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if events := client.Events("group", "stream"); len(events) != goroutines {
+		t.Fatalf("got %d events, want %d", len(events), goroutines)
+	}
+	if got := cwCore.RetryCount(); got != 0 {
+		t.Fatalf("RetryCount() = %d, want 0 (flushes of one stream should serialize instead of racing on the sequence token)", got)
+	}
+}
@@ -0,0 +1,48 @@
+// Package zapcloudwatchcoremetrics exposes a zapcloudwatchcore.CloudwatchCore
+// as Prometheus metrics. It's a separate package so that importing
+// zapcloudwatchcore doesn't pull in prometheus/client_golang for callers
+// who don't use it.
+package zapcloudwatchcoremetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/vmlellis/zapcloudwatchcore"
+)
+
+// Collector exposes a CloudwatchCore's delivery counters as Prometheus
+// metrics.
+type Collector struct {
+	core *zapcloudwatchcore.CloudwatchCore
+
+	dropped *prometheus.Desc
+	sent    *prometheus.Desc
+	retries *prometheus.Desc
+}
+
+// NewCollector returns a prometheus.Collector reporting core's
+// DroppedEvents, SentEvents, and RetryCount.
+func NewCollector(core *zapcloudwatchcore.CloudwatchCore) *Collector {
+	return &Collector{
+		core:    core,
+		dropped: prometheus.NewDesc("zapcloudwatchcore_dropped_events_total", "Events that could not be delivered to CloudWatch Logs.", nil, nil),
+		sent:    prometheus.NewDesc("zapcloudwatchcore_sent_events_total", "Events successfully delivered to CloudWatch Logs.", nil, nil),
+		retries: prometheus.NewDesc("zapcloudwatchcore_retry_total", "PutLogEvents attempts that failed and were retried.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.dropped
+	ch <- c.sent
+	ch <- c.retries
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(c.core.DroppedEvents()))
+	ch <- prometheus.MustNewConstMetric(c.sent, prometheus.CounterValue, float64(c.core.SentEvents()))
+	ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(c.core.RetryCount()))
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
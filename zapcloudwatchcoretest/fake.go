@@ -0,0 +1,205 @@
+// Package zapcloudwatchcoretest provides an in-memory fake of the
+// CloudWatch Logs API surface zapcloudwatchcore.CloudWatchLogsAPI depends
+// on, for exercising Write, sequence-token handling, and error paths
+// without talking to real AWS.
+package zapcloudwatchcoretest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+
+	"github.com/vmlellis/zapcloudwatchcore"
+)
+
+var _ zapcloudwatchcore.CloudWatchLogsAPI = (*Client)(nil)
+
+type streamKey struct {
+	group  string
+	stream string
+}
+
+type fakeStream struct {
+	events            []*cloudwatchlogs.InputLogEvent
+	nextSequenceToken string
+}
+
+type rejection int
+
+const (
+	rejectInvalidSequenceToken rejection = iota + 1
+	rejectDataAlreadyAccepted
+	rejectInvalidSequenceTokenAlways
+)
+
+// Client is an in-memory stand-in for the real CloudWatch Logs client. It
+// records every event it accepts, keyed by (group, stream).
+type Client struct {
+	mu         sync.Mutex
+	groups     map[string]bool
+	streams    map[streamKey]*fakeStream
+	rejections map[streamKey]rejection
+}
+
+// NewClient returns an empty fake client.
+func NewClient() *Client {
+	return &Client{
+		groups:     make(map[string]bool),
+		streams:    make(map[streamKey]*fakeStream),
+		rejections: make(map[streamKey]rejection),
+	}
+}
+
+// Events returns a copy of every event recorded for (group, stream).
+func (c *Client) Events(group, stream string) []*cloudwatchlogs.InputLogEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.streams[streamKey{group, stream}]
+	if !ok {
+		return nil
+	}
+	out := make([]*cloudwatchlogs.InputLogEvent, len(s.events))
+	copy(out, s.events)
+	return out
+}
+
+// RejectNextPutWithInvalidSequenceToken makes the next PutLogEvents call
+// for (group, stream) fail with InvalidSequenceTokenException, reporting
+// the stream's real expected token, so callers can test retry recovery.
+func (c *Client) RejectNextPutWithInvalidSequenceToken(group, stream string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rejections[streamKey{group, stream}] = rejectInvalidSequenceToken
+}
+
+// RejectNextPutAsDataAlreadyAccepted makes the next PutLogEvents call for
+// (group, stream) fail with DataAlreadyAcceptedException: the events are
+// recorded, as CloudWatch would have durably stored them, but the response
+// reports the error instead of success, so callers can test that they
+// don't resend an already-accepted batch.
+func (c *Client) RejectNextPutAsDataAlreadyAccepted(group, stream string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rejections[streamKey{group, stream}] = rejectDataAlreadyAccepted
+}
+
+// AlwaysRejectPutsWithInvalidSequenceToken makes every PutLogEvents call for
+// (group, stream) fail with InvalidSequenceTokenException, so callers can
+// test retry exhaustion: unlike RejectNextPutWithInvalidSequenceToken, the
+// rejection is never cleared.
+func (c *Client) AlwaysRejectPutsWithInvalidSequenceToken(group, stream string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rejections[streamKey{group, stream}] = rejectInvalidSequenceTokenAlways
+}
+
+func (c *Client) DescribeLogGroups(in *cloudwatchlogs.DescribeLogGroupsInput) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := &cloudwatchlogs.DescribeLogGroupsOutput{}
+	prefix := aws.StringValue(in.LogGroupNamePrefix)
+	if c.groups[prefix] {
+		out.LogGroups = []*cloudwatchlogs.LogGroup{{LogGroupName: aws.String(prefix)}}
+	}
+	return out, nil
+}
+
+func (c *Client) CreateLogGroup(in *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := aws.StringValue(in.LogGroupName)
+	if c.groups[name] {
+		return nil, resourceAlreadyExistsError(fmt.Sprintf("log group %q already exists", name))
+	}
+	c.groups[name] = true
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+func (c *Client) DescribeLogStreams(in *cloudwatchlogs.DescribeLogStreamsInput) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := streamKey{aws.StringValue(in.LogGroupName), aws.StringValue(in.LogStreamNamePrefix)}
+	s, ok := c.streams[key]
+	out := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	if ok {
+		stream := &cloudwatchlogs.LogStream{LogStreamName: aws.String(key.stream)}
+		if s.nextSequenceToken != "" {
+			stream.UploadSequenceToken = aws.String(s.nextSequenceToken)
+		}
+		out.LogStreams = []*cloudwatchlogs.LogStream{stream}
+	}
+	return out, nil
+}
+
+func (c *Client) CreateLogStream(in *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := streamKey{aws.StringValue(in.LogGroupName), aws.StringValue(in.LogStreamName)}
+	if _, ok := c.streams[key]; ok {
+		return nil, resourceAlreadyExistsError(fmt.Sprintf("log stream %q already exists", key.stream))
+	}
+	c.streams[key] = &fakeStream{}
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+func (c *Client) PutLogEvents(in *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := streamKey{aws.StringValue(in.LogGroupName), aws.StringValue(in.LogStreamName)}
+	s, ok := c.streams[key]
+	if !ok {
+		return nil, fmt.Errorf("zapcloudwatchcoretest: no such log stream %q", key.stream)
+	}
+
+	switch c.rejections[key] {
+	case rejectInvalidSequenceToken:
+		delete(c.rejections, key)
+		return nil, invalidSequenceTokenError(s.nextSequenceToken)
+	case rejectDataAlreadyAccepted:
+		delete(c.rejections, key)
+		s.events = append(s.events, in.LogEvents...)
+		s.nextSequenceToken = fmt.Sprintf("%d", len(s.events))
+		return nil, dataAlreadyAcceptedError(s.nextSequenceToken)
+	case rejectInvalidSequenceTokenAlways:
+		return nil, invalidSequenceTokenError(s.nextSequenceToken)
+	}
+
+	given := aws.StringValue(in.SequenceToken)
+	if given != s.nextSequenceToken {
+		return nil, invalidSequenceTokenError(s.nextSequenceToken)
+	}
+
+	s.events = append(s.events, in.LogEvents...)
+	s.nextSequenceToken = fmt.Sprintf("%d", len(s.events))
+
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String(s.nextSequenceToken)}, nil
+}
+
+func invalidSequenceTokenError(expected string) error {
+	return awserr.New(
+		cloudwatchlogs.ErrCodeInvalidSequenceTokenException,
+		fmt.Sprintf("The given sequenceToken is invalid. The next expected sequenceToken is: %s", expected),
+		nil,
+	)
+}
+
+func dataAlreadyAcceptedError(expected string) error {
+	return awserr.New(
+		cloudwatchlogs.ErrCodeDataAlreadyAcceptedException,
+		fmt.Sprintf("The given batch of log events has already been accepted. The next batch can be sent with sequenceToken: %s", expected),
+		nil,
+	)
+}
+
+func resourceAlreadyExistsError(msg string) error {
+	return awserr.New(cloudwatchlogs.ErrCodeResourceAlreadyExistsException, msg, nil)
+}